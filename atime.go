@@ -0,0 +1,47 @@
+//go:build unix
+
+package mtimehash
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// statTimes returns path's current access and modification times, following the symlink unless
+// followSymlinks is false. Callers must stat before reading or otherwise touching path, since
+// that read can itself bump the access time on atime-tracking filesystems.
+func statTimes(path string, followSymlinks bool) (atime, mtime time.Time, err error) {
+	var st unix.Stat_t
+
+	if followSymlinks {
+		err = unix.Stat(path, &st)
+	} else {
+		err = unix.Lstat(path, &st)
+	}
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("stat %s: %w", path, err)
+	}
+
+	atim, mtim := statTimespecs(&st)
+	return time.Unix(atim.Sec, atim.Nsec), time.Unix(mtim.Sec, mtim.Nsec), nil
+}
+
+// lchtimes sets path's access and modification times without following a trailing symlink, so
+// a symlink itself is retimed rather than its target. A zero atime leaves the existing access
+// time untouched, matching os.Chtimes' zero-Time semantics.
+func lchtimes(path string, atime, mtime time.Time) error {
+	atimeSpec := unix.Timespec{Nsec: unix.UTIME_OMIT}
+	if !atime.IsZero() {
+		atimeSpec = unix.NsecToTimespec(atime.UnixNano())
+	}
+	ts := []unix.Timespec{
+		atimeSpec,
+		unix.NsecToTimespec(mtime.UnixNano()),
+	}
+	if err := unix.UtimesNanoAt(unix.AT_FDCWD, path, ts, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return fmt.Errorf("lchtimes %s: %w", path, err)
+	}
+	return nil
+}
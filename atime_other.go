@@ -0,0 +1,36 @@
+//go:build !unix
+
+package mtimehash
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// statTimes returns path's current modification time, following the symlink unless
+// followSymlinks is false. Outside unix there is no portable way to read a file's access time
+// through the standard library, so atime is always reported as the zero Time, which applyMtime
+// treats as "leave the existing access time untouched".
+func statTimes(path string, followSymlinks bool) (atime, mtime time.Time, err error) {
+	var info os.FileInfo
+	if followSymlinks {
+		info, err = os.Stat(path)
+	} else {
+		info, err = os.Lstat(path)
+	}
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("stat %s: %w", path, err)
+	}
+	return time.Time{}, info.ModTime(), nil
+}
+
+// lchtimes sets path's access and modification times via os.Chtimes. Unlike its unix
+// counterpart it follows a trailing symlink, since there is no portable symlink-safe retime
+// outside unix.
+func lchtimes(path string, atime, mtime time.Time) error {
+	if err := os.Chtimes(path, atime, mtime); err != nil {
+		return fmt.Errorf("lchtimes %s: %w", path, err)
+	}
+	return nil
+}
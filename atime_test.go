@@ -0,0 +1,138 @@
+package mtimehash
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getAtime returns path's current access time, following symlinks.
+func getAtime(t *testing.T, path string) time.Time {
+	t.Helper()
+	atime, _, err := statTimes(path, true)
+	require.NoError(t, err)
+	return atime
+}
+
+func TestProcessWithOptions_Atime(t *testing.T) {
+	files := setupFiles(t, map[string]string{"a.txt": "aaa"})
+	filePath := files[0]
+
+	atime := time.Unix(1000000, 0)
+	require.NoError(t, os.Chtimes(filePath, atime, time.Unix(2000000, 0)))
+
+	t.Run("preserves atime by default", func(t *testing.T) {
+		require.NoError(t, ProcessWithOptions(slices.Values(files), Options{MaxUnixTime: 1000000000}))
+		assert.Equal(t, atime.Unix(), getAtime(t, filePath).Unix())
+	})
+
+	t.Run("leaves atime untouched when PreserveAtime is disabled", func(t *testing.T) {
+		require.NoError(t, os.Chtimes(filePath, atime, time.Unix(2000000, 0)))
+		disabled := false
+
+		require.NoError(t, ProcessWithOptions(slices.Values(files), Options{MaxUnixTime: 1000000000, PreserveAtime: &disabled}))
+
+		// Disabling PreserveAtime means mtimehash makes no attempt to restore the original
+		// atime, not that it actively clears it — the filesystem's own atime semantics apply,
+		// so the value set just above should be left alone rather than corrupted to garbage.
+		assert.Equal(t, atime.Unix(), getAtime(t, filePath).Unix())
+	})
+}
+
+func TestProcessWithOptions_FollowSymlinks(t *testing.T) {
+	files := setupFiles(t, map[string]string{"a.txt": "aaa"})
+	target := files[0]
+	link := filepath.Join(filepath.Dir(target), "link.txt")
+	require.NoError(t, os.Symlink(target, link))
+
+	linkBefore, err := os.Lstat(link)
+	require.NoError(t, err)
+	targetBefore, err := os.Stat(target)
+	require.NoError(t, err)
+
+	t.Run("does not follow symlinks by default", func(t *testing.T) {
+		require.NoError(t, ProcessWithOptions(slices.Values([]string{link}), Options{MaxUnixTime: 1000000000}))
+
+		linkAfter, err := os.Lstat(link)
+		require.NoError(t, err)
+		assert.NotEqual(t, linkBefore.ModTime(), linkAfter.ModTime())
+
+		targetAfter, err := os.Stat(target)
+		require.NoError(t, err)
+		assert.Equal(t, targetBefore.ModTime(), targetAfter.ModTime())
+	})
+}
+
+func TestProcessDirectoriesWithOptions_Atime(t *testing.T) {
+	tempDir := t.TempDir()
+	dir := filepath.Join(tempDir, "dir")
+	require.NoError(t, os.Mkdir(dir, 0o777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("content"), 0o666))
+
+	atime := time.Unix(1000000, 0)
+	require.NoError(t, os.Chtimes(dir, atime, time.Unix(2000000, 0)))
+
+	require.NoError(t, ProcessDirectoriesWithOptions(slices.Values([]string{dir}), Options{MaxUnixTime: 1000000000}))
+
+	assert.Equal(t, atime.Unix(), getAtime(t, dir).Unix())
+}
+
+func TestProcessTree_Atime(t *testing.T) {
+	root := t.TempDir()
+	filePath := filepath.Join(root, "a.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte("content"), 0o666))
+
+	fileAtime := time.Unix(1000000, 0)
+	require.NoError(t, os.Chtimes(filePath, fileAtime, time.Unix(2000000, 0)))
+	dirAtime := time.Unix(3000000, 0)
+	require.NoError(t, os.Chtimes(root, dirAtime, time.Unix(4000000, 0)))
+
+	require.NoError(t, ProcessTree(root, Options{MaxUnixTime: 1000000000}))
+
+	assert.Equal(t, fileAtime.Unix(), getAtime(t, filePath).Unix())
+	assert.Equal(t, dirAtime.Unix(), getAtime(t, root).Unix())
+}
+
+func TestProcessTree_FollowSymlinks(t *testing.T) {
+	root := t.TempDir()
+	targetDir := t.TempDir()
+	targetPath := filepath.Join(targetDir, "target.txt")
+	require.NoError(t, os.WriteFile(targetPath, []byte("content"), 0o666))
+
+	link := filepath.Join(root, "link.txt")
+	require.NoError(t, os.Symlink(targetPath, link))
+
+	linkBefore, err := os.Lstat(link)
+	require.NoError(t, err)
+
+	t.Run("does not touch the link's target by default", func(t *testing.T) {
+		targetBefore, err := os.Stat(targetPath)
+		require.NoError(t, err)
+
+		require.NoError(t, ProcessTree(root, Options{MaxUnixTime: 1000000000}))
+
+		linkAfter, err := os.Lstat(link)
+		require.NoError(t, err)
+		assert.Equal(t, linkBefore.ModTime(), linkAfter.ModTime())
+
+		targetAfter, err := os.Stat(targetPath)
+		require.NoError(t, err)
+		assert.Equal(t, targetBefore.ModTime(), targetAfter.ModTime())
+	})
+
+	t.Run("retimes the target in place of the link when following", func(t *testing.T) {
+		targetBefore, err := os.Stat(targetPath)
+		require.NoError(t, err)
+
+		require.NoError(t, ProcessTree(root, Options{MaxUnixTime: 1000000000, Symlinks: SymlinkFollow}))
+
+		targetAfter, err := os.Stat(targetPath)
+		require.NoError(t, err)
+		assert.NotEqual(t, targetBefore.ModTime(), targetAfter.ModTime())
+	})
+}
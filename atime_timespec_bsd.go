@@ -0,0 +1,11 @@
+//go:build freebsd || netbsd || openbsd || dragonfly
+
+package mtimehash
+
+import "golang.org/x/sys/unix"
+
+// statTimespecs extracts the access and modification timespecs from st. The BSDs share Linux's
+// Stat_t field names (Atim/Mtim), unlike darwin.
+func statTimespecs(st *unix.Stat_t) (atime, mtime unix.Timespec) {
+	return st.Atim, st.Mtim
+}
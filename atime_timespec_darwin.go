@@ -0,0 +1,9 @@
+package mtimehash
+
+import "golang.org/x/sys/unix"
+
+// statTimespecs extracts the access and modification timespecs from st, accounting for darwin's
+// Stat_t field names (Atimespec/Mtimespec).
+func statTimespecs(st *unix.Stat_t) (atime, mtime unix.Timespec) {
+	return st.Atimespec, st.Mtimespec
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package mtimehash
+
+import "os"
+
+// dirKeyOf always reports ok == false on non-unix platforms, since there's no portable
+// device/inode pair to key a directory on. As a result, ProcessTree's symlink-cycle detection
+// is a unix-only safeguard outside of this build.
+func dirKeyOf(info os.FileInfo) (dirKey, bool) {
+	return dirKey{}, false
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package mtimehash
+
+import (
+	"os"
+	"syscall"
+)
+
+// dirKeyOf returns the dirKey for info and whether one could be determined. It is only
+// meaningful for directories; non-directories report ok == false.
+func dirKeyOf(info os.FileInfo) (dirKey, bool) {
+	if !info.IsDir() {
+		return dirKey{}, false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return dirKey{}, false
+	}
+	return dirKey{dev: uint64(st.Dev), ino: st.Ino}, true
+}
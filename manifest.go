@@ -0,0 +1,58 @@
+package mtimehash
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"iter"
+	"log/slog"
+	"sync"
+
+	"github.com/sourcegraph/conc/pool"
+)
+
+// ManifestRecord is one NDJSON record written by ProcessWithManifest for a processed file.
+type ManifestRecord struct {
+	Path          string `json:"path"`
+	Algorithm     string `json:"algorithm"`
+	Hash          string `json:"hash"`
+	UnixTime      int64  `json:"unix_time"`
+	PreviousMtime int64  `json:"previous_mtime"`
+}
+
+// ProcessWithManifest processes input files like Process, updating each file's mtime, and
+// streams one JSON object per processed file to w: its path, hash algorithm, full hex-encoded
+// hash, derived unix time, and previous mtime. Records are written as each file finishes under
+// a shared mutex, so w receives valid NDJSON even while files are processed concurrently.
+func ProcessWithManifest(input iter.Seq[string], w io.Writer, opts Options) error {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+
+	p := pool.New().WithErrors().WithMaxGoroutines(opts.concurrency())
+	for filePath := range input {
+		p.Go(func() error {
+			entry, err := planFile(filePath, opts)
+			if err != nil {
+				slog.Default().Error("failed to process file", "file", filePath, "err", err)
+				return err
+			}
+			if err := applyMtime(filePath, entry, opts); err != nil {
+				slog.Default().Error("failed to process file", "file", filePath, "err", err)
+				return err
+			}
+
+			record := ManifestRecord{
+				Path:          filePath,
+				Algorithm:     opts.algorithmName(),
+				Hash:          hex.EncodeToString(entry.Hash),
+				UnixTime:      entry.NewMtime.Unix(),
+				PreviousMtime: entry.OldMtime.Unix(),
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			return enc.Encode(record)
+		})
+	}
+	return p.Wait()
+}
@@ -0,0 +1,42 @@
+package mtimehash
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessWithManifest(t *testing.T) {
+	fileToContent := map[string]string{
+		"a.txt": "aaa",
+		"b.txt": "bbb",
+	}
+	files := setupFiles(t, fileToContent)
+
+	var buf bytes.Buffer
+	require.NoError(t, ProcessWithManifest(slices.Values(files), &buf, Options{MaxUnixTime: 1000000000}))
+
+	records := make(map[string]ManifestRecord)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var record ManifestRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records[record.Path] = record
+	}
+	require.Len(t, records, len(files))
+
+	mtimes := getMtimes(t, files)
+	for _, filePath := range files {
+		record, ok := records[filePath]
+		require.True(t, ok, "missing manifest record for %s", filePath)
+		assert.Equal(t, "sha256", record.Algorithm)
+		assert.NotEmpty(t, record.Hash)
+		assert.Equal(t, mtimes[path.Base(filePath)], record.UnixTime)
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
-	"io"
+	"hash"
 	"iter"
 	"log/slog"
+	"math"
 	"os"
 	"runtime"
 	"sort"
@@ -15,13 +16,105 @@ import (
 	"github.com/sourcegraph/conc/pool"
 )
 
+// Options configures how Process and ProcessDirectories derive mtimes from content.
+type Options struct {
+	// Hasher constructs the hash.Hash implementation used to derive mtimes.
+	// Defaults to sha256.New when nil, which lets callers swap in MD5, SHA-1,
+	// SHA-512, BLAKE2b/BLAKE3, or any other hash.Hash constructor.
+	Hasher func() hash.Hash
+
+	// MaxUnixTime bounds the derived mtime: the hash is reduced modulo this value. Defaults to
+	// math.MaxInt64 (reduction only into the int64 range) when <= 0.
+	MaxUnixTime int64
+
+	// Concurrency caps the number of files/directories processed at once.
+	// Defaults to runtime.GOMAXPROCS(0) when <= 0.
+	Concurrency int
+
+	// DirsOnly, when used with ProcessTree, sets only directory mtimes and leaves file mtimes
+	// untouched. When false (the default), ProcessTree sets both.
+	DirsOnly bool
+
+	// Symlinks controls how ProcessTree treats symlinks encountered during the walk. The zero
+	// value, SymlinkSkip, leaves symlinks untouched and out of their parent's hash.
+	Symlinks SymlinkMode
+
+	// AlgorithmName labels the hash algorithm in ProcessWithManifest's output. Defaults to
+	// "sha256" when Hasher is nil, or "custom" when Hasher is set without a name.
+	AlgorithmName string
+
+	// PreserveAtime controls whether a file's existing access time is kept when its mtime is
+	// updated, instead of passing Chtimes a zero atime. Defaults to true when nil; set it to a
+	// pointer to false to opt out.
+	PreserveAtime *bool
+
+	// FollowSymlinks controls how a path that is itself a symlink is treated. When false (the
+	// zero value), the symlink itself is retimed via lchtimes, leaving its target untouched.
+	// When true, the symlink's target is retimed instead, matching plain os.Chtimes. Process
+	// and ProcessDirectories always set it to true.
+	FollowSymlinks bool
+}
+
+// hasher returns the configured hash constructor, defaulting to sha256.New.
+func (o Options) hasher() func() hash.Hash {
+	if o.Hasher != nil {
+		return o.Hasher
+	}
+	return sha256.New
+}
+
+// concurrency returns the configured goroutine cap, defaulting to GOMAXPROCS.
+func (o Options) concurrency() int {
+	if o.Concurrency <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Concurrency
+}
+
+// algorithmName returns the configured algorithm label, defaulting based on Hasher.
+func (o Options) algorithmName() string {
+	if o.AlgorithmName != "" {
+		return o.AlgorithmName
+	}
+	if o.Hasher == nil {
+		return "sha256"
+	}
+	return "custom"
+}
+
+// preserveAtime reports whether the file's access time should be kept, defaulting to true.
+func (o Options) preserveAtime() bool {
+	if o.PreserveAtime == nil {
+		return true
+	}
+	return *o.PreserveAtime
+}
+
+// maxUnixTime returns the configured modulus for reducing a hash to a timestamp, defaulting to
+// math.MaxInt64 when unset, since the zero value would otherwise divide by zero in hashToTime.
+func (o Options) maxUnixTime() int64 {
+	if o.MaxUnixTime <= 0 {
+		return math.MaxInt64
+	}
+	return o.MaxUnixTime
+}
+
 // Process input files and update their modification time based on the hash of their content.
-// The modification time is set to the hash modulo maxUnixTime.
+// The modification time is set to the hash modulo maxUnixTime. A path that is itself a symlink
+// has its target retimed, matching plain os.Chtimes; use ProcessWithOptions with
+// FollowSymlinks: false to retime the symlink itself instead. It uses SHA-256; to pick a
+// different hash algorithm or tune concurrency, use ProcessWithOptions.
 func Process(input iter.Seq[string], maxUnixTime int64) error {
-	p := pool.New().WithErrors().WithMaxGoroutines(runtime.GOMAXPROCS(0))
+	return ProcessWithOptions(input, Options{MaxUnixTime: maxUnixTime, FollowSymlinks: true})
+}
+
+// ProcessWithOptions processes input files like Process, but lets callers choose the hash
+// algorithm and concurrency via Options.
+func ProcessWithOptions(input iter.Seq[string], opts Options) error {
+	p := pool.New().WithErrors().WithMaxGoroutines(opts.concurrency())
 	for filePath := range input {
 		p.Go(func() error {
-			err := updateMtime(filePath, maxUnixTime)
+			err := updateMtime(filePath, opts)
 			if err != nil {
 				slog.Default().Error("failed to process file", "file", filePath, "err", err)
 			}
@@ -37,47 +130,38 @@ func hashToTime(h64 uint64, maxUnixTime int64) time.Time {
 	return time.Unix(int64(sec), 0)
 }
 
-// updateMtime updates the file's modification time
-func updateMtime(filePath string, maxUnixTime int64) error {
-	logger := slog.Default()
-
-	f, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("open file: %w", err)
-	}
-	defer f.Close()
+// mtimeFromSum derives a timestamp from a hash's first 8 bytes, like hashToTime but starting
+// from the raw hash.Sum output rather than an already-extracted uint64.
+func mtimeFromSum(sum []byte, maxUnixTime int64) time.Time {
+	return hashToTime(binary.BigEndian.Uint64(sum[:8]), maxUnixTime)
+}
 
-	s, err := f.Stat()
+// updateMtime updates the file's modification time
+func updateMtime(filePath string, opts Options) error {
+	entry, err := planFile(filePath, opts)
 	if err != nil {
-		return fmt.Errorf("stat file: %w", err)
-	}
-	if !s.Mode().IsRegular() {
-		return fmt.Errorf("%s is not a regular file, got %s", filePath, s.Mode())
-	}
-
-	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return fmt.Errorf("hash file: %w", err)
+		return err
 	}
-	h64 := binary.BigEndian.Uint64(h.Sum(nil)[:8]) // take first 8 bytes of the hash
-	mtime := hashToTime(h64, maxUnixTime)
-
-	if err := os.Chtimes(filePath, time.Time{}, mtime); err != nil {
-		return fmt.Errorf("set mtime: %w", err)
-	}
-
-	logger.Debug("updated file modification time", "file", filePath, "mtime", mtime)
-	return nil
+	return applyMtime(filePath, entry, opts)
 }
 
 // ProcessDirectories processes directories and updates their modification time based on their contents.
 // The mtime is set based on a hash of the directory entries (sorted names), making it deterministic
-// based on what files/subdirectories are present.
+// based on what files/subdirectories are present. A path that is itself a symlink has its target
+// retimed, matching plain os.Chtimes; use ProcessDirectoriesWithOptions with FollowSymlinks: false
+// to retime the symlink itself instead. It uses SHA-256; to pick a different hash algorithm or
+// tune concurrency, use ProcessDirectoriesWithOptions.
 func ProcessDirectories(input iter.Seq[string], maxUnixTime int64) error {
-	p := pool.New().WithErrors().WithMaxGoroutines(runtime.GOMAXPROCS(0))
+	return ProcessDirectoriesWithOptions(input, Options{MaxUnixTime: maxUnixTime, FollowSymlinks: true})
+}
+
+// ProcessDirectoriesWithOptions processes directories like ProcessDirectories, but lets callers
+// choose the hash algorithm and concurrency via Options.
+func ProcessDirectoriesWithOptions(input iter.Seq[string], opts Options) error {
+	p := pool.New().WithErrors().WithMaxGoroutines(opts.concurrency())
 	for dirPath := range input {
 		p.Go(func() error {
-			err := updateDirMtime(dirPath, maxUnixTime)
+			err := updateDirMtime(dirPath, opts)
 			if err != nil {
 				slog.Default().Error("failed to process directory", "dir", dirPath, "err", err)
 			}
@@ -88,8 +172,12 @@ func ProcessDirectories(input iter.Seq[string], maxUnixTime int64) error {
 }
 
 // updateDirMtime updates the directory's modification time based on its contents
-func updateDirMtime(dirPath string, maxUnixTime int64) error {
-	logger := slog.Default()
+func updateDirMtime(dirPath string, opts Options) error {
+	// Stat before reading the directory, since os.ReadDir can itself bump its access time.
+	atime, oldMtime, err := statTimes(dirPath, opts.FollowSymlinks)
+	if err != nil {
+		return err
+	}
 
 	// Read directory contents
 	entries, err := os.ReadDir(dirPath)
@@ -98,7 +186,7 @@ func updateDirMtime(dirPath string, maxUnixTime int64) error {
 	}
 
 	// Create deterministic hash based on directory entries
-	h := sha256.New()
+	h := opts.hasher()()
 
 	// Sort entries by name for determinism
 	entryNames := make([]string, 0, len(entries))
@@ -121,13 +209,13 @@ func updateDirMtime(dirPath string, maxUnixTime int64) error {
 		}
 	}
 
-	h64 := binary.BigEndian.Uint64(h.Sum(nil)[:8])
-	mtime := hashToTime(h64, maxUnixTime)
-
-	if err := os.Chtimes(dirPath, time.Time{}, mtime); err != nil {
-		return fmt.Errorf("set directory mtime: %w", err)
+	sum := h.Sum(nil)
+	entry := PlanEntry{
+		Path:     dirPath,
+		OldMtime: oldMtime,
+		OldAtime: atime,
+		NewMtime: mtimeFromSum(sum, opts.maxUnixTime()),
+		Hash:     sum,
 	}
-
-	logger.Debug("updated directory modification time", "dir", dirPath, "mtime", mtime)
-	return nil
+	return applyMtime(dirPath, entry, opts)
 }
@@ -66,6 +66,15 @@ func TestProcess(t *testing.T) {
 	})
 }
 
+func TestProcessWithOptions_DefaultMaxUnixTime(t *testing.T) {
+	files := setupFiles(t, map[string]string{"a.txt": "aaa"})
+
+	require.NoError(t, ProcessWithOptions(slices.Values(files), Options{}))
+
+	mtimes := getMtimes(t, files)
+	assert.NotZero(t, mtimes[path.Base(files[0])])
+}
+
 func setupFiles(t *testing.T, files map[string]string) []string {
 	t.Helper()
 	tempDir := t.TempDir()
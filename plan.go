@@ -0,0 +1,127 @@
+package mtimehash
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/conc/pool"
+)
+
+// PlanEntry describes the hash-derived mtime computed for a single file by Plan.
+type PlanEntry struct {
+	// Path is the file path, as given in Plan's input.
+	Path string
+	// OldMtime is the file's modification time before any change.
+	OldMtime time.Time
+	// OldAtime is the file's access time before any change, captured before its content was
+	// read so the read itself doesn't get mistaken for the "original" atime.
+	OldAtime time.Time
+	// NewMtime is the modification time Process would set, derived from Hash.
+	NewMtime time.Time
+	// Hash is the full content hash the new mtime was derived from.
+	Hash []byte
+}
+
+// Plan computes the same hash-derived mtime Process would set for each input file, without
+// calling os.Chtimes. This lets callers preview changes, emit an audit report, or check in CI
+// that a tree is already normalized, failing instead of silently rewriting timestamps.
+func Plan(input iter.Seq[string], opts Options) (iter.Seq2[string, PlanEntry], error) {
+	var mu sync.Mutex
+	entries := make(map[string]PlanEntry)
+	var paths []string
+
+	p := pool.New().WithErrors().WithMaxGoroutines(opts.concurrency())
+	for filePath := range input {
+		p.Go(func() error {
+			entry, err := planFile(filePath, opts)
+			if err != nil {
+				slog.Default().Error("failed to plan file", "file", filePath, "err", err)
+				return err
+			}
+
+			mu.Lock()
+			entries[filePath] = entry
+			paths = append(paths, filePath)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := p.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return func(yield func(string, PlanEntry) bool) {
+		for _, filePath := range paths {
+			if !yield(filePath, entries[filePath]) {
+				return
+			}
+		}
+	}, nil
+}
+
+// planFile computes the PlanEntry for a single file without mutating it.
+func planFile(filePath string, opts Options) (PlanEntry, error) {
+	// Stat before opening the file, since reading its content can itself bump its access time.
+	atime, oldMtime, err := statTimes(filePath, opts.FollowSymlinks)
+	if err != nil {
+		return PlanEntry{}, err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return PlanEntry{}, fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	s, err := f.Stat()
+	if err != nil {
+		return PlanEntry{}, fmt.Errorf("stat file: %w", err)
+	}
+	if !s.Mode().IsRegular() {
+		return PlanEntry{}, fmt.Errorf("%s is not a regular file, got %s", filePath, s.Mode())
+	}
+
+	h := opts.hasher()()
+	if _, err := io.Copy(h, f); err != nil {
+		return PlanEntry{}, fmt.Errorf("hash file: %w", err)
+	}
+	sum := h.Sum(nil)
+
+	return PlanEntry{
+		Path:     filePath,
+		OldMtime: oldMtime,
+		OldAtime: atime,
+		NewMtime: mtimeFromSum(sum, opts.maxUnixTime()),
+		Hash:     sum,
+	}, nil
+}
+
+// applyMtime sets filePath's modification time to entry.NewMtime, honoring opts.PreserveAtime
+// and opts.FollowSymlinks. entry.OldAtime must have been captured before filePath's content was
+// read, since the read itself can bump the access time on atime-tracking filesystems.
+func applyMtime(filePath string, entry PlanEntry, opts Options) error {
+	atime := time.Time{}
+	if opts.preserveAtime() {
+		atime = entry.OldAtime
+	}
+
+	var err error
+	if opts.FollowSymlinks {
+		err = os.Chtimes(filePath, atime, entry.NewMtime)
+	} else {
+		err = lchtimes(filePath, atime, entry.NewMtime)
+	}
+	if err != nil {
+		return fmt.Errorf("set mtime: %w", err)
+	}
+
+	slog.Default().Debug("updated modification time", "path", filePath, "mtime", entry.NewMtime)
+	return nil
+}
@@ -0,0 +1,41 @@
+package mtimehash
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	fileToContent := map[string]string{
+		"a.txt": "aaa",
+		"b.txt": "bbb",
+	}
+	files := setupFiles(t, fileToContent)
+
+	t.Run("computes mtimes without mutating files", func(t *testing.T) {
+		before := getMtimes(t, files)
+
+		seq, err := Plan(slices.Values(files), Options{MaxUnixTime: 1000000000})
+		require.NoError(t, err)
+
+		entries := make(map[string]PlanEntry)
+		for path, entry := range seq {
+			entries[filepath.Base(path)] = entry
+		}
+
+		assert.Equal(t, before, getMtimes(t, files))
+		assert.Equal(t, int64(259627185), entries["a.txt"].NewMtime.Unix())
+		assert.Equal(t, int64(613142970), entries["b.txt"].NewMtime.Unix())
+		assert.NotEmpty(t, entries["a.txt"].Hash)
+		assert.Equal(t, before["a.txt"], entries["a.txt"].OldMtime.Unix())
+	})
+
+	t.Run("errors on unreadable file", func(t *testing.T) {
+		_, err := Plan(slices.Values([]string{"nonexistent.txt"}), Options{MaxUnixTime: 1000000000})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,221 @@
+package mtimehash
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SymlinkMode controls how ProcessTree treats symlinks encountered during the walk.
+type SymlinkMode int
+
+const (
+	// SymlinkSkip leaves a symlink untouched and excludes it from its parent's hash.
+	SymlinkSkip SymlinkMode = iota
+	// SymlinkFollow treats a symlink as its target: the target is hashed and retimed in place
+	// of the symlink, just as a file or directory reached directly would be.
+	SymlinkFollow
+	// SymlinkHashPath includes the symlink's target path in its parent's hash, without
+	// following or modifying the symlink or its target.
+	SymlinkHashPath
+)
+
+// ProcessTree walks root recursively and derives a Merkle-style mtime for every file and
+// directory from its content: leaf files are hashed from their bytes first, then each
+// directory's hash rolls up the sorted (name, isDir, childHash) tuples of its entries, so a
+// directory's mtime changes whenever anything beneath it changes. Use Options.DirsOnly and
+// Options.Symlinks to control file mtimes and symlink handling.
+func ProcessTree(root string, opts Options) error {
+	_, err := hashTree(root, opts, make(map[dirKey]bool))
+	return err
+}
+
+// dirKey identifies a directory by device and inode, which stays stable across the different
+// paths (direct or via a symlink) that can reach it.
+type dirKey struct {
+	dev, ino uint64
+}
+
+// dirKeyOf returns the dirKey for info and whether one could be determined. It is only
+// meaningful for directories; non-directories report ok == false. Its implementation is
+// platform-specific: see dirkey_unix.go and dirkey_other.go.
+
+// hashTree hashes path, which may be a file, directory, or symlink, updates its mtime as
+// directed by opts, and returns the raw hash bytes so a parent directory can roll them up.
+// ancestors tracks the directories currently being walked (by device and inode), so that
+// following a symlink back into an ancestor is reported as an error instead of recursing
+// forever.
+func hashTree(path string, opts Options, ancestors map[dirKey]bool) ([]byte, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, fmt.Errorf("lstat %s: %w", path, err)
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return hashSymlink(path, opts, ancestors)
+	case info.IsDir():
+		return hashDir(path, info, opts, ancestors)
+	default:
+		return hashLeafFile(path, opts)
+	}
+}
+
+// dirEntryHash is one rolled-up (name, isDir, hash) tuple contributing to a directory's hash.
+type dirEntryHash struct {
+	name  string
+	isDir bool
+	hash  []byte
+}
+
+// hashDir recursively hashes dirPath's entries and rolls them up into the directory's own hash.
+// info is dirPath's already-Lstat'd info, used to detect a symlink cycle back into dirPath.
+func hashDir(dirPath string, info os.FileInfo, opts Options, ancestors map[dirKey]bool) ([]byte, error) {
+	if key, ok := dirKeyOf(info); ok {
+		if ancestors[key] {
+			return nil, fmt.Errorf("symlink cycle detected at %s", dirPath)
+		}
+		ancestors[key] = true
+		defer delete(ancestors, key)
+	}
+
+	// Stat before reading the directory, since os.ReadDir can itself bump its access time.
+	atime, oldMtime, err := statTimes(dirPath, opts.FollowSymlinks)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("read directory: %w", err)
+	}
+
+	// os.ReadDir returns entries sorted by filename, so the rolled-up tuples are deterministic.
+	var children []dirEntryHash
+	for _, entry := range entries {
+		childPath := filepath.Join(dirPath, entry.Name())
+		isSymlink := entry.Type()&os.ModeSymlink != 0
+
+		if isSymlink && opts.Symlinks == SymlinkSkip {
+			continue
+		}
+
+		childHash, err := hashTree(childPath, opts, ancestors)
+		if err != nil {
+			return nil, err
+		}
+
+		isDir := entry.IsDir()
+		if isSymlink && opts.Symlinks == SymlinkFollow {
+			targetInfo, err := os.Stat(childPath)
+			if err != nil {
+				return nil, fmt.Errorf("stat symlink target %s: %w", childPath, err)
+			}
+			isDir = targetInfo.IsDir()
+		}
+
+		children = append(children, dirEntryHash{name: entry.Name(), isDir: isDir, hash: childHash})
+	}
+
+	h := opts.hasher()()
+	for _, c := range children {
+		h.Write([]byte(c.name))
+		if c.isDir {
+			h.Write([]byte("/"))
+		}
+		h.Write(c.hash)
+	}
+	sum := h.Sum(nil)
+	entry := PlanEntry{
+		Path:     dirPath,
+		OldMtime: oldMtime,
+		OldAtime: atime,
+		NewMtime: mtimeFromSum(sum, opts.maxUnixTime()),
+		Hash:     sum,
+	}
+	if err := applyMtime(dirPath, entry, opts); err != nil {
+		return nil, err
+	}
+	return sum, nil
+}
+
+// hashLeafFile hashes a regular file's content and, unless opts.DirsOnly is set, updates its
+// mtime. It rejects anything that isn't a regular file: reading a FIFO with no writer or a
+// device node would otherwise block or stream unbounded data through io.Copy.
+func hashLeafFile(filePath string, opts Options) ([]byte, error) {
+	// Stat before opening the file, since reading its content can itself bump its access time.
+	atime, oldMtime, err := statTimes(filePath, opts.FollowSymlinks)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the mode before opening, since opening a FIFO with no writer blocks.
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file, got %s", filePath, info.Mode())
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	s, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+	if !s.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file, got %s", filePath, s.Mode())
+	}
+
+	h := opts.hasher()()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("hash file: %w", err)
+	}
+	sum := h.Sum(nil)
+
+	if !opts.DirsOnly {
+		entry := PlanEntry{
+			Path:     filePath,
+			OldMtime: oldMtime,
+			OldAtime: atime,
+			NewMtime: mtimeFromSum(sum, opts.maxUnixTime()),
+			Hash:     sum,
+		}
+		if err := applyMtime(filePath, entry, opts); err != nil {
+			return nil, err
+		}
+	}
+	return sum, nil
+}
+
+// hashSymlink hashes a symlink according to opts.Symlinks, following it or hashing the target
+// path. Under SymlinkSkip, hashDir drops symlink entries before this is reached; only a
+// symlink root gets here, and it yields a nil hash and is left untouched. ancestors is passed through to
+// hashTree so that following a symlink into a directory already being walked is caught as a
+// cycle instead of recursing forever.
+func hashSymlink(path string, opts Options, ancestors map[dirKey]bool) ([]byte, error) {
+	switch opts.Symlinks {
+	case SymlinkFollow:
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return nil, fmt.Errorf("resolve symlink %s: %w", path, err)
+		}
+		return hashTree(target, opts, ancestors)
+	case SymlinkHashPath:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, fmt.Errorf("read symlink %s: %w", path, err)
+		}
+		h := opts.hasher()()
+		h.Write([]byte(target))
+		return h.Sum(nil), nil
+	default: // SymlinkSkip
+		return nil, nil
+	}
+}
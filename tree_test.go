@@ -0,0 +1,84 @@
+package mtimehash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessTree(t *testing.T) {
+	buildTree := func(t *testing.T, bContent string) string {
+		t.Helper()
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("aaa"), 0o666))
+		sub := filepath.Join(root, "sub")
+		require.NoError(t, os.Mkdir(sub, 0o777))
+		require.NoError(t, os.WriteFile(filepath.Join(sub, "b.txt"), []byte(bContent), 0o666))
+		return root
+	}
+
+	t.Run("file content changes propagate up", func(t *testing.T) {
+		root1 := buildTree(t, "bbb")
+		root2 := buildTree(t, "ccc")
+
+		require.NoError(t, ProcessTree(root1, Options{MaxUnixTime: 1000000000}))
+		require.NoError(t, ProcessTree(root2, Options{MaxUnixTime: 1000000000}))
+
+		rootMtime1 := getMtimes(t, []string{root1})[filepath.Base(root1)]
+		rootMtime2 := getMtimes(t, []string{root2})[filepath.Base(root2)]
+		assert.NotEqual(t, rootMtime1, rootMtime2)
+
+		subMtime1 := getMtimes(t, []string{filepath.Join(root1, "sub")})["sub"]
+		subMtime2 := getMtimes(t, []string{filepath.Join(root2, "sub")})["sub"]
+		assert.NotEqual(t, subMtime1, subMtime2)
+	})
+
+	t.Run("DirsOnly leaves file mtimes untouched", func(t *testing.T) {
+		root := buildTree(t, "bbb")
+		filePath := filepath.Join(root, "a.txt")
+		before, err := os.Stat(filePath)
+		require.NoError(t, err)
+
+		require.NoError(t, ProcessTree(root, Options{MaxUnixTime: 1000000000, DirsOnly: true}))
+
+		after, err := os.Stat(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, before.ModTime(), after.ModTime())
+
+		dirMtime := getMtimes(t, []string{filepath.Join(root, "sub")})["sub"]
+		assert.NotZero(t, dirMtime)
+	})
+
+	t.Run("symlinks skipped by default", func(t *testing.T) {
+		root := buildTree(t, "bbb")
+		require.NoError(t, os.Symlink(filepath.Join(root, "a.txt"), filepath.Join(root, "link")))
+
+		require.NoError(t, ProcessTree(root, Options{MaxUnixTime: 1000000000}))
+	})
+
+	t.Run("following a symlink cycle errors instead of hanging", func(t *testing.T) {
+		root := t.TempDir()
+		dirA := filepath.Join(root, "a")
+		dirB := filepath.Join(root, "b")
+		require.NoError(t, os.Mkdir(dirA, 0o777))
+		require.NoError(t, os.Mkdir(dirB, 0o777))
+		require.NoError(t, os.Symlink(dirB, filepath.Join(dirA, "linkToB")))
+		require.NoError(t, os.Symlink(dirA, filepath.Join(dirB, "linkToA")))
+
+		done := make(chan error, 1)
+		go func() {
+			done <- ProcessTree(root, Options{MaxUnixTime: 1000000000, Symlinks: SymlinkFollow})
+		}()
+
+		select {
+		case err := <-done:
+			assert.Error(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("ProcessTree did not return within 5s, likely stuck in a symlink cycle")
+		}
+	})
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package mtimehash
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestProcessTree_RejectsNonRegularFile(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, unix.Mkfifo(filepath.Join(root, "fifo"), 0o666))
+
+	err := ProcessTree(root, Options{MaxUnixTime: 1000000000})
+	assert.ErrorContains(t, err, "not a regular file")
+}